@@ -0,0 +1,210 @@
+package simplelru
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// AdmissionPolicy gets a say over whether a brand new key may evict the
+// current LRU tail. Record is called on every access (hit or insertion) so
+// the policy can build up a frequency estimate; Admit is only consulted
+// when an insertion would otherwise force an eviction; Reset lets the
+// policy age out stale frequency information.
+type AdmissionPolicy interface {
+	Record(key interface{})
+	Admit(newKey, victimKey interface{}) bool
+	Reset()
+}
+
+// noopAdmissionPolicy admits everything, reproducing the unconditional
+// insert behavior of the original LRU.Add.
+type noopAdmissionPolicy struct{}
+
+func (noopAdmissionPolicy) Record(interface{})                  {}
+func (noopAdmissionPolicy) Admit(interface{}, interface{}) bool { return true }
+func (noopAdmissionPolicy) Reset()                              {}
+
+const (
+	tinyLFUDepth        = 4
+	tinyLFUWidthPerItem = 10
+	tinyLFUMaxCounter   = 15 // counters are 4 bits wide
+)
+
+// TinyLFU is an AdmissionPolicy that estimates each key's access frequency
+// with a Count-Min Sketch, guarded by a doorkeeper bloom filter so that a
+// key seen only once never pollutes the sketch. It admits a new key over
+// the current LRU tail only when the new key's estimated frequency is at
+// least as high as the victim's, which keeps scan-heavy or one-hit-wonder
+// workloads from flushing out well-established entries.
+type TinyLFU struct {
+	mu sync.Mutex
+
+	width    uint32
+	counters []byte // depth rows of width 4-bit counters, nibble-packed
+	seeds    [tinyLFUDepth]uint64
+
+	doorkeeper     []uint64 // bitset, width bits
+	doorkeeperSeed uint64
+
+	increments uint64
+	sampleSize uint64
+}
+
+// NewTinyLFU constructs a TinyLFU admission policy sized for roughly
+// capacity distinct items; the sketch width scales with capacity, not with
+// value size, so memory use stays bounded regardless of what's cached.
+func NewTinyLFU(capacity int) *TinyLFU {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	width := uint32(capacity * tinyLFUWidthPerItem)
+	if width < 16 {
+		width = 16
+	}
+	nibbles := uint64(tinyLFUDepth) * uint64(width)
+	t := &TinyLFU{
+		width:          width,
+		counters:       make([]byte, (nibbles+1)/2),
+		doorkeeper:     make([]uint64, (uint64(width)+63)/64),
+		doorkeeperSeed: 0x9ae16a3b2f90404f,
+		sampleSize:     uint64(width) * tinyLFUDepth,
+	}
+	for i := range t.seeds {
+		t.seeds[i] = fnvSeed ^ (0x9e3779b97f4a7c15 * uint64(i+1))
+	}
+	return t
+}
+
+var _ AdmissionPolicy = (*TinyLFU)(nil)
+
+const fnvSeed = 0xcbf29ce484222325
+
+// hashKey turns an arbitrary key into a 64-bit hash. string and []byte, the
+// common case, take the same direct FNV-1a path as ShardedLRU's shardFor;
+// anything else falls back to hashing its %v representation, since this
+// package already treats keys as opaque interface{} values with no
+// required Hash method.
+func hashKey(key interface{}) uint64 {
+	switch k := key.(type) {
+	case string:
+		return fnv1a64String(k)
+	case []byte:
+		return fnv1a64(k)
+	default:
+		h := fnv.New64a()
+		fmt.Fprint(h, key)
+		return h.Sum64()
+	}
+}
+
+func (t *TinyLFU) indexFor(h uint64, row int) uint32 {
+	mixed := h ^ t.seeds[row]
+	mixed ^= mixed >> 33
+	mixed *= 0xff51afd7ed558ccd
+	mixed ^= mixed >> 33
+	return uint32(mixed % uint64(t.width))
+}
+
+func (t *TinyLFU) counterIndex(row int, col uint32) (byteIndex int, high bool) {
+	flat := uint64(row)*uint64(t.width) + uint64(col)
+	return int(flat / 2), flat%2 == 0
+}
+
+func (t *TinyLFU) getCounter(row int, col uint32) byte {
+	idx, high := t.counterIndex(row, col)
+	b := t.counters[idx]
+	if high {
+		return b >> 4
+	}
+	return b & 0x0F
+}
+
+func (t *TinyLFU) incrCounter(row int, col uint32) {
+	idx, high := t.counterIndex(row, col)
+	b := t.counters[idx]
+	if high {
+		if v := b >> 4; v < tinyLFUMaxCounter {
+			t.counters[idx] = ((v + 1) << 4) | (b & 0x0F)
+		}
+		return
+	}
+	if v := b & 0x0F; v < tinyLFUMaxCounter {
+		t.counters[idx] = (b & 0xF0) | (v + 1)
+	}
+}
+
+func (t *TinyLFU) doorkeeperTest(h uint64) bool {
+	idx := uint32(h % uint64(t.width))
+	word, bit := idx/64, idx%64
+	return t.doorkeeper[word]&(1<<bit) != 0
+}
+
+func (t *TinyLFU) doorkeeperSet(h uint64) {
+	idx := uint32(h % uint64(t.width))
+	word, bit := idx/64, idx%64
+	t.doorkeeper[word] |= 1 << bit
+}
+
+// Record increments the frequency estimate for key. The first sighting of a
+// key only sets its doorkeeper bit; only a repeat sighting touches the
+// sketch, so one-hit wonders never get an estimate above zero.
+func (t *TinyLFU) Record(key interface{}) {
+	h := hashKey(key) ^ t.doorkeeperSeed
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.doorkeeperTest(h) {
+		t.doorkeeperSet(h)
+		return
+	}
+
+	base := hashKey(key)
+	for row := 0; row < tinyLFUDepth; row++ {
+		t.incrCounter(row, t.indexFor(base, row))
+	}
+	t.increments++
+	if t.increments >= t.sampleSize {
+		t.resetLocked()
+	}
+}
+
+// Admit reports whether newKey should be allowed to evict victimKey. Ties
+// favor the new key, matching the common TinyLFU convention of letting
+// fresh entries through when frequency is indistinguishable.
+func (t *TinyLFU) Admit(newKey, victimKey interface{}) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.estimateLocked(newKey) >= t.estimateLocked(victimKey)
+}
+
+func (t *TinyLFU) estimateLocked(key interface{}) byte {
+	h := hashKey(key)
+	min := byte(tinyLFUMaxCounter)
+	for row := 0; row < tinyLFUDepth; row++ {
+		if v := t.getCounter(row, t.indexFor(h, row)); v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// Reset halves every counter and clears the doorkeeper, letting recency
+// slowly win out over historical frequency instead of frequency estimates
+// growing unbounded and sticking forever.
+func (t *TinyLFU) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resetLocked()
+}
+
+func (t *TinyLFU) resetLocked() {
+	for i, b := range t.counters {
+		t.counters[i] = ((b >> 4) / 2 << 4) | ((b & 0x0F) / 2)
+	}
+	for i := range t.doorkeeper {
+		t.doorkeeper[i] = 0
+	}
+	t.increments = 0
+}
@@ -0,0 +1,48 @@
+package simplelru
+
+import "testing"
+
+func TestTinyLFU_RepeatedKeyOutscoresOneHitWonder(t *testing.T) {
+	tlfu := NewTinyLFU(100)
+
+	for i := 0; i < 5; i++ {
+		tlfu.Record("hot")
+	}
+	tlfu.Record("cold")
+
+	if !tlfu.Admit("hot", "cold") {
+		t.Fatal("a repeatedly-seen key should be admitted over a one-hit wonder")
+	}
+}
+
+func TestTinyLFU_OneHitWonderNeverPollutesSketch(t *testing.T) {
+	tlfu := NewTinyLFU(100)
+	tlfu.Record("once")
+
+	if got := tlfu.estimateLocked("once"); got != 0 {
+		t.Fatalf("got estimate %d for a key seen once, want 0", got)
+	}
+}
+
+func TestTinyLFU_ResetHalvesCounters(t *testing.T) {
+	tlfu := NewTinyLFU(100)
+	for i := 0; i < 4; i++ {
+		tlfu.Record("k")
+	}
+	before := tlfu.estimateLocked("k")
+	if before == 0 {
+		t.Fatal("expected a nonzero estimate before Reset")
+	}
+
+	tlfu.Reset()
+	if got := tlfu.estimateLocked("k"); got >= before {
+		t.Fatalf("got estimate %d after Reset, want less than %d", got, before)
+	}
+}
+
+func TestHashKey_StringAndBytesAgree(t *testing.T) {
+	s := "some-key"
+	if hashKey(s) != hashKey([]byte(s)) {
+		t.Fatal("hashKey should hash a string and its []byte form identically")
+	}
+}
@@ -0,0 +1,270 @@
+package simplelru
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ARC implements a size-aware Adaptive Replacement Cache. It keeps two real
+// sub-caches, t1 (recently added) and t2 (recently reused), and two ghost
+// sub-caches, b1 and b2, that remember the keys (but not the values) most
+// recently evicted from t1 and t2. A hit in b1 or b2 nudges the target size
+// p of t1 up or down, so the balance between recency (t1) and frequency
+// (t2) adapts to the workload without any tuning parameters.
+type ARC struct {
+	mu sync.Mutex
+
+	sizeLimit int
+	p         int // target size, in bytes, for t1
+
+	t1 *LRU
+	b1 *LRU
+	t2 *LRU
+	b2 *LRU
+
+	onEvict EvictCallback
+}
+
+var _ LRUCache = (*ARC)(nil)
+
+// NewARC constructs an adaptive replacement cache occupying approximately
+// the given size in memory.
+func NewARC(sizeLimit int, ttl time.Duration, onEvict EvictCallback) (*ARC, error) {
+	if sizeLimit <= 0 {
+		return nil, errors.New("Must provide a positive size limit")
+	}
+
+	t1, err := NewLRUWithTTL(sizeLimit, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	b1, err := NewLRU(sizeLimit, nil)
+	if err != nil {
+		return nil, err
+	}
+	t2, err := NewLRUWithTTL(sizeLimit, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	b2, err := NewLRU(sizeLimit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ARC{
+		sizeLimit: sizeLimit,
+		t1:        t1,
+		b1:        b1,
+		t2:        t2,
+		b2:        b2,
+		onEvict:   onEvict,
+	}, nil
+}
+
+// Get looks up a key's value from the cache. A hit in t1 promotes the entry
+// to t2, since being accessed a second time makes it a frequent entry.
+func (c *ARC) Get(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, ok = c.t1.Peek(key); ok {
+		size, _ := c.t1.peekSize(key)
+		c.t1.Remove(key)
+		c.t2.Add(key, value, size)
+		return value, true
+	}
+
+	return c.t2.Get(key)
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *ARC) Add(key, value interface{}, size int) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.t1.Contains(key) {
+		c.t1.Remove(key)
+		evicted = c.replace(size, false)
+		c.t2.Add(key, value, size)
+		return evicted
+	}
+	if c.t2.Contains(key) {
+		oldSize, _ := c.t2.peekSize(key)
+		if delta := size - oldSize; delta > 0 {
+			evicted = c.replace(delta, false)
+		}
+		c.t2.Add(key, value, size)
+		return evicted
+	}
+
+	if c.b1.Contains(key) {
+		c.p = min(c.p+c.adaptDelta(c.b1, c.b2), c.sizeLimit)
+		c.b1.Remove(key)
+		evicted = c.replace(size, false)
+		c.t2.Add(key, value, size)
+		return evicted
+	}
+	if c.b2.Contains(key) {
+		c.p = max(c.p-c.adaptDelta(c.b2, c.b1), 0)
+		c.b2.Remove(key)
+		evicted = c.replace(size, true)
+		c.t2.Add(key, value, size)
+		return evicted
+	}
+
+	evicted = c.replace(size, false)
+	c.t1.Add(key, value, size)
+	return evicted
+}
+
+// adaptDelta computes how far to move p on a ghost hit in "from", scaled by
+// how much bigger "other" is, matching the original ARC adaptation rule.
+func (c *ARC) adaptDelta(from, other *LRU) int {
+	fromSize, otherSize := from.Size(), other.Size()
+	if fromSize == 0 {
+		return 1
+	}
+	if otherSize > fromSize {
+		if d := otherSize / fromSize; d > 1 {
+			return d
+		}
+	}
+	return 1
+}
+
+// replace evicts from t1 or t2, whichever the current target size p says is
+// over-represented, moving the evicted key into the matching ghost cache,
+// until there is room for an incoming entry of the given size. c.mu must be
+// held.
+func (c *ARC) replace(incomingSize int, b2Ghost bool) (evicted bool) {
+	for c.t1.Size()+c.t2.Size()+incomingSize > c.sizeLimit {
+		if c.t1.Size() > 0 && (c.t1.Size() > c.p || (c.t1.Size() == c.p && b2Ghost) || c.t2.Size() == 0) {
+			key, value, size, ok := c.t1.removeOldestWithSize()
+			if !ok {
+				break
+			}
+			c.b1.Add(key, nil, size)
+			if c.onEvict != nil {
+				c.onEvict(key, value, size, EvictReasonSize)
+			}
+		} else {
+			key, value, size, ok := c.t2.removeOldestWithSize()
+			if !ok {
+				break
+			}
+			c.b2.Add(key, nil, size)
+			if c.onEvict != nil {
+				c.onEvict(key, value, size, EvictReasonSize)
+			}
+		}
+		evicted = true
+	}
+	return evicted
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (c *ARC) Contains(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t1.Contains(key) || c.t2.Contains(key)
+}
+
+// Peek returns the key value without updating the recent-ness of the key.
+func (c *ARC) Peek(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok = c.t1.Peek(key); ok {
+		return value, true
+	}
+	return c.t2.Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *ARC) Remove(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.t1.Remove(key) {
+		return true
+	}
+	if c.t2.Remove(key) {
+		return true
+	}
+	if c.b1.Remove(key) {
+		return true
+	}
+	return c.b2.Remove(key)
+}
+
+// RemoveOldest removes the oldest item from the cache, preferring t1 over
+// t2 as dictated by ARC's recency-over-frequency tie-break.
+func (c *ARC) RemoveOldest() (key, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, value, ok = c.t1.GetOldest(); ok {
+		c.t1.Remove(key)
+		return key, value, true
+	}
+	if key, value, ok = c.t2.GetOldest(); ok {
+		c.t2.Remove(key)
+		return key, value, true
+	}
+	return nil, nil, false
+}
+
+// GetOldest returns the oldest entry, preferring t1 over t2.
+func (c *ARC) GetOldest() (key, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, value, ok = c.t1.GetOldest(); ok {
+		return key, value, true
+	}
+	return c.t2.GetOldest()
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest
+// within each sub-cache; t1 keys are listed before t2 keys.
+func (c *ARC) Keys() []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(c.t1.Keys(), c.t2.Keys()...)
+}
+
+// Len returns the number of items in the cache.
+func (c *ARC) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t1.Len() + c.t2.Len()
+}
+
+// Size returns the current size of the cache, excluding the ghost lists.
+func (c *ARC) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t1.Size() + c.t2.Size()
+}
+
+// Purge clears t1, t2, b1 and b2.
+func (c *ARC) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t1.Purge()
+	c.t2.Purge()
+	c.b1.Purge()
+	c.b2.Purge()
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
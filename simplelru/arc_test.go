@@ -0,0 +1,90 @@
+package simplelru
+
+import "testing"
+
+func TestARC_GetPromotesT1ToT2(t *testing.T) {
+	var evicted []interface{}
+	c, err := NewARC(10, 0, func(key, _ interface{}, _ int, _ EvictReason) {
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be present")
+	}
+	if len(evicted) != 0 {
+		t.Fatalf("got evicted %v from a live promotion, want none", evicted)
+	}
+	if _, ok := c.t2.Peek("a"); !ok {
+		t.Fatal("a should have been promoted to t2")
+	}
+}
+
+func TestARC_GhostHitAdaptsP(t *testing.T) {
+	c, err := NewARC(2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	// Evicts a into b1.
+	c.Add("c", 3, 1)
+	if !c.b1.Contains("a") {
+		t.Fatal("a should have been ghosted into b1")
+	}
+
+	pBefore := c.p
+	c.Add("a", 4, 1)
+	if c.p <= pBefore {
+		t.Fatalf("got p=%d after a b1 ghost hit, want it to grow past %d", c.p, pBefore)
+	}
+	if !c.t2.Contains("a") {
+		t.Fatal("a should re-enter via t2 on a b1 ghost hit")
+	}
+}
+
+// TestARC_UpdateInPlaceRespectsSizeLimit is a regression test: growing the
+// size of a key already resident in t2 must make room the same way a
+// brand new key does, instead of writing the new size straight in and
+// blowing past sizeLimit.
+func TestARC_UpdateInPlaceRespectsSizeLimit(t *testing.T) {
+	c, err := NewARC(10, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Get("a") // promote a into t2
+	if _, ok := c.t2.Peek("a"); !ok {
+		t.Fatal("a should have been promoted to t2")
+	}
+	c.Add("b", 2, 1)
+
+	c.Add("a", 3, 10) // growing a by 9 bytes no longer fits alongside b
+	if got := c.Size(); got > 10 {
+		t.Fatalf("got Size=%d after growing a resident key, want at most 10", got)
+	}
+	if c.Contains("b") {
+		t.Fatal("b should have been evicted to make room for a's growth")
+	}
+}
+
+func TestARC_EvictsOverSizeLimit(t *testing.T) {
+	c, err := NewARC(2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	if !c.Add("c", 3, 1) {
+		t.Fatal("expected Add to report an eviction")
+	}
+	if c.Len() > 2 {
+		t.Fatalf("got Len=%d, want at most 2", c.Len())
+	}
+}
@@ -0,0 +1,44 @@
+package simplelru
+
+// LRUCache is the interface implemented by the size-aware cache policies in
+// this package (LRU, TwoQueueLRU, ARC). It lets the thread-safe wrapper
+// packages depend on a policy rather than a concrete type, so callers can
+// choose the eviction strategy that best fits their workload at
+// construction time.
+type LRUCache interface {
+	// Add adds a value to the cache, returning true if an eviction occurred.
+	Add(key, value interface{}, size int) bool
+
+	// Get looks up a key's value from the cache, updating its recent-ness.
+	Get(key interface{}) (value interface{}, ok bool)
+
+	// Contains checks if a key is in the cache, without updating the
+	// recent-ness.
+	Contains(key interface{}) bool
+
+	// Peek returns the key value without updating the recent-ness.
+	Peek(key interface{}) (value interface{}, ok bool)
+
+	// Remove removes the provided key from the cache.
+	Remove(key interface{}) bool
+
+	// RemoveOldest removes the oldest item from the cache.
+	RemoveOldest() (key interface{}, value interface{}, ok bool)
+
+	// GetOldest returns the oldest entry.
+	GetOldest() (key interface{}, value interface{}, ok bool)
+
+	// Keys returns a slice of the keys in the cache, from oldest to newest.
+	Keys() []interface{}
+
+	// Len returns the number of items in the cache.
+	Len() int
+
+	// Size returns the current size of the cache.
+	Size() int
+
+	// Purge completely clears the cache.
+	Purge()
+}
+
+var _ LRUCache = (*LRU)(nil)
@@ -0,0 +1,71 @@
+package simplelru
+
+// InvalidationEvent describes a single invalidation published by
+// ChannelSink. Key is nil when Purge is true.
+type InvalidationEvent struct {
+	CacheName string
+	Key       interface{}
+	Purge     bool
+}
+
+// ChannelSink is a reference InvalidationSink that publishes events onto a
+// buffered channel. Sends are non-blocking: a full channel drops the event
+// rather than stalling the cache operation that triggered it, so a slow or
+// absent consumer can't add latency to Remove/Purge.
+type ChannelSink struct {
+	events chan InvalidationEvent
+}
+
+var _ InvalidationSink = (*ChannelSink)(nil)
+
+// NewChannelSink constructs a ChannelSink with the given channel buffer
+// size.
+func NewChannelSink(buffer int) *ChannelSink {
+	return &ChannelSink{events: make(chan InvalidationEvent, buffer)}
+}
+
+// Events returns the channel invalidation events are published to.
+func (s *ChannelSink) Events() <-chan InvalidationEvent {
+	return s.events
+}
+
+// OnRemove implements InvalidationSink.
+func (s *ChannelSink) OnRemove(name string, key interface{}) {
+	select {
+	case s.events <- InvalidationEvent{CacheName: name, Key: key}:
+	default:
+	}
+}
+
+// OnPurge implements InvalidationSink.
+func (s *ChannelSink) OnPurge(name string) {
+	select {
+	case s.events <- InvalidationEvent{CacheName: name, Purge: true}:
+	default:
+	}
+}
+
+// InvalidationSinkFunc adapts a pair of functions to the InvalidationSink
+// interface, so callers can wire a cache to NATS, Redis pub/sub, or a
+// Mattermost-style cluster bus with a couple of closures instead of a named
+// type. Either field may be left nil to ignore that notification.
+type InvalidationSinkFunc struct {
+	Remove func(name string, key interface{})
+	Purge  func(name string)
+}
+
+var _ InvalidationSink = InvalidationSinkFunc{}
+
+// OnRemove implements InvalidationSink.
+func (f InvalidationSinkFunc) OnRemove(name string, key interface{}) {
+	if f.Remove != nil {
+		f.Remove(name, key)
+	}
+}
+
+// OnPurge implements InvalidationSink.
+func (f InvalidationSinkFunc) OnPurge(name string) {
+	if f.Purge != nil {
+		f.Purge(name)
+	}
+}
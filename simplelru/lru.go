@@ -3,20 +3,96 @@ package simplelru
 import (
 	"container/list"
 	"errors"
+	"sync"
 	"time"
 )
 
-// EvictCallback is used to get a callback when a cache entry is evicted
-type EvictCallback func(key interface{}, value interface{}, size int)
+// EvictReason says why an entry left the cache, so an EvictCallback or
+// InvalidationSink can tell a deliberate Remove from routine TTL/size
+// churn.
+type EvictReason int
 
-// LRU implements a non-thread safe size-aware LRU cache
+const (
+	// EvictReasonSize means the entry was evicted to make room for a new
+	// or updated entry under the size limit, or was taken off the tail by
+	// RemoveOldest.
+	EvictReasonSize EvictReason = iota
+	// EvictReasonExpired means the entry's ttl had elapsed.
+	EvictReasonExpired
+	// EvictReasonRemoved means the caller explicitly removed the entry
+	// via Remove.
+	EvictReasonRemoved
+	// EvictReasonPurge means the entry left as part of a Purge.
+	EvictReasonPurge
+)
+
+// EvictCallback is used to get a callback when a cache entry is evicted.
+//
+// Except during Purge (see Purge's doc comment), onEvict is invoked
+// synchronously while the cache's internal mutex is held, so that a caller
+// watching RemoveOldest/GetOldest's return value and the callback's view of
+// the cache never disagree about what just left. A slow callback therefore
+// stalls every other goroutine calling into this cache, and a callback that
+// itself calls back into the same cache will deadlock: keep it fast, and
+// don't call Add/Get/Remove/etc. on the originating cache from within it.
+type EvictCallback func(key interface{}, value interface{}, size int, reason EvictReason)
+
+// LegacyEvictCallback is the pre-EvictReason callback shape. Existing
+// callers can keep using it by wrapping it with AdaptLegacyEvictCallback.
+type LegacyEvictCallback func(key interface{}, value interface{}, size int)
+
+// AdaptLegacyEvictCallback adapts a LegacyEvictCallback to the current
+// EvictCallback shape, discarding the reason. Returns nil if cb is nil.
+func AdaptLegacyEvictCallback(cb LegacyEvictCallback) EvictCallback {
+	if cb == nil {
+		return nil
+	}
+	return func(key, value interface{}, size int, _ EvictReason) {
+		cb(key, value, size)
+	}
+}
+
+// InvalidationSink is notified of explicit removals and purges so that a
+// cluster of caches can be kept coherent, e.g. by publishing invalidation
+// events to NATS, Redis pub/sub, or a Mattermost-style cluster bus. The
+// core package has no knowledge of any transport; see ChannelSink and
+// InvalidationSinkFunc for ways to wire one up.
+type InvalidationSink interface {
+	// OnRemove is called after an explicit Remove of key from the named
+	// cache. It is not called for TTL or size-driven evictions.
+	OnRemove(name string, key interface{})
+	// OnPurge is called after the named cache is purged.
+	OnPurge(name string)
+}
+
+// LRU implements a size-aware LRU cache with optional TTL-based expiration.
+//
+// Purge is O(1): rather than walking every entry, it swaps in a fresh
+// evictList/items pair. Every read path looks an entry up through items
+// first, so once Purge has swapped it out there is no way to reach it
+// again through this cache.
+//
+// LRU guards its state with an internal mutex so that a janitor goroutine
+// started via StartJanitor can safely expire entries in the background
+// while callers continue to Get/Add/Remove.
 type LRU struct {
-	currentSize int
-	sizeLimit   int
-	evictList   *list.List
-	items       map[interface{}]*list.Element
-	onEvict     EvictCallback
-	ttl         time.Duration
+	mu sync.Mutex
+
+	// Name identifies this cache to an InvalidationSink. Set it directly
+	// after construction; it is otherwise unused by LRU itself.
+	Name string
+
+	currentSize      int
+	sizeLimit        int
+	evictList        *list.List
+	items            map[interface{}]*list.Element
+	onEvict          EvictCallback
+	ttl              time.Duration
+	admission        AdmissionPolicy
+	invalidationSink InvalidationSink
+
+	janitorStop chan struct{}
+	janitorDone chan struct{}
 }
 
 // entry is used to hold a value in the evictList
@@ -27,7 +103,7 @@ type entry struct {
 	expire time.Time
 }
 
-func (e entry) isExpired() bool {
+func (e *entry) isExpired() bool {
 	return !e.expire.IsZero() && time.Now().After(e.expire)
 }
 
@@ -38,34 +114,77 @@ func NewLRU(sizeLimit int, onEvict EvictCallback) (*LRU, error) {
 
 // NewLRUWithTTL constructs a LRU cache with a ttl for elements
 func NewLRUWithTTL(sizeLimit int, ttl time.Duration, onEvict EvictCallback) (*LRU, error) {
+	return NewLRUWithAdmission(sizeLimit, ttl, noopAdmissionPolicy{}, onEvict)
+}
+
+// NewLRUWithAdmission constructs a LRU cache with a ttl for elements and an
+// admission policy that gets a say over whether a brand new key is allowed
+// to evict the current tail. Pass a noopAdmissionPolicy{} (what NewLRU and
+// NewLRUWithTTL use) to preserve the unconditional-insert behavior.
+func NewLRUWithAdmission(sizeLimit int, ttl time.Duration, admission AdmissionPolicy, onEvict EvictCallback) (*LRU, error) {
 	if sizeLimit <= 0 {
 		return nil, errors.New("Must provide a positive size limit")
 	}
+	if admission == nil {
+		admission = noopAdmissionPolicy{}
+	}
 	c := &LRU{
 		sizeLimit: sizeLimit,
 		evictList: list.New(),
 		items:     make(map[interface{}]*list.Element),
 		onEvict:   onEvict,
 		ttl:       ttl,
+		admission: admission,
 	}
 	return c, nil
 }
 
-// Purge is used to completely clear the cache.
+// Purge is used to completely clear the cache in O(1) time. Unlike every
+// other eviction path (see EvictCallback), onEvict here is invoked for the
+// purged entries from a background goroutine rather than synchronously,
+// since a Purge can hand the callback an entire cache's worth of entries at
+// once and callers shouldn't pay the cost of walking them all before Purge
+// returns.
 func (c *LRU) Purge() {
-	for k, v := range c.items {
-		if c.onEvict != nil {
-			e := v.Value.(*entry)
-			c.onEvict(k, e.value, e.size)
-		}
-		delete(c.items, k)
-	}
-	c.evictList.Init()
+	c.mu.Lock()
+	oldItems := c.items
+	cb := c.onEvict
+	sink := c.invalidationSink
+	name := c.Name
+	c.evictList = list.New()
+	c.items = make(map[interface{}]*list.Element)
 	c.currentSize = 0
+	c.mu.Unlock()
+
+	if cb != nil && len(oldItems) > 0 {
+		go func() {
+			for k, v := range oldItems {
+				e := v.Value.(*entry)
+				cb(k, e.value, e.size, EvictReasonPurge)
+			}
+		}()
+	}
+	if sink != nil {
+		go sink.OnPurge(name)
+	}
+}
+
+// SetInvalidationSink wires sink to receive OnRemove/OnPurge notifications
+// for this cache's explicit removals and purges. Pass nil to stop
+// notifying.
+func (c *LRU) SetInvalidationSink(sink InvalidationSink) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.invalidationSink = sink
 }
 
 // Add adds a value to the cache.  Returns true if an eviction occurred.
 func (c *LRU) Add(key, value interface{}, size int) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.admission.Record(key)
+
 	// Check for existing item
 	if ent, ok := c.items[key]; ok {
 		c.evictList.MoveToFront(ent)
@@ -80,17 +199,26 @@ func (c *LRU) Add(key, value interface{}, size int) (evicted bool) {
 		return false
 	}
 
+	// A brand new key that would force the current tail out gets a say from
+	// the admission policy first, so scan-heavy workloads can't flush out
+	// well-established entries with a stream of one-hit wonders.
+	if victim, wouldEvict := c.wouldEvictLocked(size); wouldEvict {
+		if !c.admission.Admit(key, victim) {
+			return false
+		}
+	}
+
 	// Add new item
 	ent := &entry{key: key, value: value, size: size}
 	if c.ttl != 0 {
 		ent.expire = time.Now().Add(c.ttl)
 	}
-	entry := c.evictList.PushFront(ent)
-	c.items[key] = entry
+	element := c.evictList.PushFront(ent)
+	c.items[key] = element
 	c.currentSize += size
 
 	for c.sizeLimit < c.currentSize {
-		c.removeOldest()
+		c.removeOldestLocked()
 		evicted = true
 	}
 	return evicted
@@ -98,26 +226,33 @@ func (c *LRU) Add(key, value interface{}, size int) (evicted bool) {
 
 // Get looks up a key's value from the cache.
 func (c *LRU) Get(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if ent, ok := c.items[key]; ok {
 		e := ent.Value.(*entry)
 		if e.isExpired() {
-			c.removeElement(ent)
+			c.removeElementLocked(ent, EvictReasonExpired)
 			return nil, false
 		}
+		c.admission.Record(key)
 		c.evictList.MoveToFront(ent)
 		return e.value, true
 	}
-	return
+	return nil, false
 }
 
 // Contains checks if a key is in the cache, without updating the
-// recent-ness. It may delete it if the key expired
+// recent-ness. It may delete it if the key has expired.
 func (c *LRU) Contains(key interface{}) (ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	ent, ok := c.items[key]
 	if ok {
 		e := ent.Value.(*entry)
 		if e.isExpired() {
-			c.removeElement(ent)
+			c.removeElementLocked(ent, EvictReasonExpired)
 			ok = false
 		}
 	}
@@ -127,23 +262,29 @@ func (c *LRU) Contains(key interface{}) (ok bool) {
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *LRU) Peek(key interface{}) (value interface{}, ok bool) {
-	var ent *list.Element
-	if ent, ok = c.items[key]; ok {
-		e := ent.Value.(*entry)
-		if e.isExpired() {
-			c.removeElement(ent)
-			return nil, false
-		}
-		return e.value, true
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ent, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := ent.Value.(*entry)
+	if e.isExpired() {
+		c.removeElementLocked(ent, EvictReasonExpired)
+		return nil, false
 	}
-	return nil, ok
+	return e.value, true
 }
 
 // Remove removes the provided key from the cache, returning if the
 // key was contained.
 func (c *LRU) Remove(key interface{}) (present bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if ent, ok := c.items[key]; ok {
-		c.removeElement(ent)
+		c.removeElementLocked(ent, EvictReasonRemoved)
 		return true
 	}
 	return false
@@ -151,69 +292,211 @@ func (c *LRU) Remove(key interface{}) (present bool) {
 
 // RemoveOldest removes the oldest item from the cache.
 func (c *LRU) RemoveOldest() (key interface{}, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
 		kv := ent.Value.(*entry)
-		return kv.key, kv.value, true
+		key, value = kv.key, kv.value
+		c.removeElementLocked(ent, EvictReasonSize)
+		return key, value, true
 	}
 	return nil, nil, false
 }
 
 // GetOldest returns the oldest entry
 func (c *LRU) GetOldest() (key interface{}, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	for {
 		ent := c.evictList.Back()
-		if ent != nil {
-			kv := ent.Value.(*entry)
-			if kv.isExpired() {
-				c.removeElement(ent)
-				continue
-			}
-			return kv.key, kv.value, true
-		} else {
-			break
+		if ent == nil {
+			return nil, nil, false
+		}
+		kv := ent.Value.(*entry)
+		if kv.isExpired() {
+			c.removeElementLocked(ent, EvictReasonExpired)
+			continue
 		}
+		return kv.key, kv.value, true
 	}
-	return nil, nil, false
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *LRU) Keys() []interface{} {
-	keys := make([]interface{}, len(c.items))
-	i := 0
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]interface{}, 0, len(c.items))
 	for ent := c.evictList.Back(); ent != nil; ent = ent.Prev() {
-		keys[i] = ent.Value.(*entry).key
-		i++
+		e := ent.Value.(*entry)
+		keys = append(keys, e.key)
 	}
 	return keys
 }
 
 // Len returns the number of items in the cache.
 func (c *LRU) Len() int {
-	return c.evictList.Len()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
 }
 
 // Size returns the current size of the cache.
 func (c *LRU) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.currentSize
 }
 
-// removeOldest removes the oldest item from the cache.
-func (c *LRU) removeOldest() {
+// StartJanitor starts a background goroutine that periodically walks the
+// tail of the evictList, removing expired entries so that Get/Contains/Peek
+// on the read path don't pay the cost of discovering TTL expiration
+// themselves. Calling StartJanitor while one is already running is a no-op.
+func (c *LRU) StartJanitor(interval time.Duration) {
+	c.mu.Lock()
+	if c.janitorStop != nil {
+		c.mu.Unlock()
+		return
+	}
+	c.janitorStop = make(chan struct{})
+	c.janitorDone = make(chan struct{})
+	stop := c.janitorStop
+	done := c.janitorDone
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.sweepExpired()
+			}
+		}
+	}()
+}
+
+// StopJanitor stops the background janitor goroutine started by
+// StartJanitor, blocking until it has exited. Calling StopJanitor when no
+// janitor is running is a no-op.
+func (c *LRU) StopJanitor() {
+	c.mu.Lock()
+	stop := c.janitorStop
+	done := c.janitorDone
+	c.janitorStop = nil
+	c.janitorDone = nil
+	c.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// sweepExpired removes expired entries from the tail of the evictList. It
+// stops at the first unexpired entry. The list is ordered by recency of use,
+// not by expiration time: Get moves an entry to the front without
+// refreshing its expiration, so an expired entry that sits in front of an
+// unexpired one is not removed by this sweep and is left to the read paths.
+func (c *LRU) sweepExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for {
+		ent := c.evictList.Back()
+		if ent == nil {
+			return
+		}
+		e := ent.Value.(*entry)
+		if !e.isExpired() {
+			return
+		}
+		c.removeElementLocked(ent, EvictReasonExpired)
+	}
+}
+
+// peekSize returns the size recorded for key without affecting recency. It
+// is used internally by sibling cache policies (TwoQueueLRU, ARC) that need
+// to move an entry between sub-caches while preserving its accounted size.
+func (c *LRU) peekSize(key interface{}) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ent, ok := c.items[key]
+	if !ok {
+		return 0, false
+	}
+	e := ent.Value.(*entry)
+	if e.isExpired() {
+		return 0, false
+	}
+	return e.size, true
+}
+
+// removeOldestWithSize removes and returns the oldest item along with its
+// recorded size. It is used internally by sibling cache policies that need
+// to move an evicted entry into a ghost cache.
+func (c *LRU) removeOldestWithSize() (key, value interface{}, size int, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ent := c.evictList.Back()
+	if ent == nil {
+		return nil, nil, 0, false
+	}
+	kv := ent.Value.(*entry)
+	key, value, size = kv.key, kv.value, kv.size
+	c.removeElementLocked(ent, EvictReasonSize)
+	return key, value, size, true
+}
+
+// wouldEvictLocked reports whether adding an entry of the given size would
+// push the cache over its size limit, and if so, which key currently sits
+// at the tail and would be the first one evicted. c.mu must be held.
+func (c *LRU) wouldEvictLocked(size int) (victim interface{}, wouldEvict bool) {
+	if c.currentSize+size <= c.sizeLimit {
+		return nil, false
+	}
+	ent := c.evictList.Back()
+	if ent == nil {
+		return nil, false
+	}
+	return ent.Value.(*entry).key, true
+}
+
+// removeOldestLocked removes the oldest item from the cache. c.mu must be held.
+func (c *LRU) removeOldestLocked() {
 	ent := c.evictList.Back()
 	if ent != nil {
-		c.removeElement(ent)
+		c.removeElementLocked(ent, EvictReasonSize)
 	}
 }
 
-// removeElement is used to remove a given list element from the cache
-func (c *LRU) removeElement(e *list.Element) {
+// removeElementLocked is used to remove a given list element from the
+// cache. c.mu must be held. onEvict is called synchronously here, per the
+// contract on the EvictCallback type; unlike Purge, a single-entry removal
+// has no large batch to dispatch off-lock, so there's nothing to gain by
+// making the caller wait for a goroutine instead of the callback itself.
+// The sink only hears about EvictReasonRemoved, per the InvalidationSink
+// contract: cluster peers care about explicit invalidations, not routine
+// TTL/size churn each node handles on its own. Unlike onEvict, the sink is
+// notified from a separate goroutine so a slow or blocking sink (e.g. a
+// network publish) can't add latency to a caller holding c.mu.
+func (c *LRU) removeElementLocked(e *list.Element, reason EvictReason) {
 	c.evictList.Remove(e)
 	kv := e.Value.(*entry)
 	delete(c.items, kv.key)
 	c.currentSize -= kv.size
 	if c.onEvict != nil {
-		c.onEvict(kv.key, kv.value, kv.size)
+		c.onEvict(kv.key, kv.value, kv.size, reason)
+	}
+	if reason == EvictReasonRemoved && c.invalidationSink != nil {
+		sink, name, key := c.invalidationSink, c.Name, kv.key
+		go sink.OnRemove(name, key)
 	}
 }
@@ -0,0 +1,147 @@
+package simplelru
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestLRU_EvictsOverSizeLimit(t *testing.T) {
+	var evicted []interface{}
+	c, err := NewLRU(3, func(key, _ interface{}, _ int, reason EvictReason) {
+		if reason != EvictReasonSize {
+			t.Fatalf("got reason %v, want EvictReasonSize", reason)
+		}
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	c.Add("c", 3, 1)
+	if c.Add("d", 4, 1) != true {
+		t.Fatal("expected Add to report an eviction")
+	}
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("got evicted %v, want [a]", evicted)
+	}
+	if c.Contains("a") {
+		t.Fatal("a should have been evicted")
+	}
+	if !c.Contains("d") {
+		t.Fatal("d should be present")
+	}
+}
+
+func TestLRU_PurgeIsReusable(t *testing.T) {
+	c, err := NewLRU(10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	c.Purge()
+	if c.Len() != 0 || c.Size() != 0 {
+		t.Fatalf("got Len=%d Size=%d after Purge, want 0, 0", c.Len(), c.Size())
+	}
+	if c.Contains("a") {
+		t.Fatal("a should not survive a Purge")
+	}
+	c.Add("c", 3, 1)
+	if !c.Contains("c") {
+		t.Fatal("cache should accept new entries after Purge")
+	}
+}
+
+func TestLRU_TTLExpiry(t *testing.T) {
+	c, err := NewLRUWithTTL(10, 10*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add("a", 1, 1)
+	if !c.Contains("a") {
+		t.Fatal("a should be present immediately after Add")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if c.Contains("a") {
+		t.Fatal("a should have expired")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("Get should not return an expired entry")
+	}
+}
+
+func TestLRU_JanitorSweepsExpiredEntries(t *testing.T) {
+	c, err := NewLRUWithTTL(10, 5*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Add("a", 1, 1)
+	if c.Len() != 1 {
+		t.Fatalf("got Len=%d right after Add, want 1", c.Len())
+	}
+
+	c.StartJanitor(5 * time.Millisecond)
+	defer c.StopJanitor()
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	// Len, unlike Contains/Get, never lazily removes anything itself, so a
+	// drop to 0 here can only be the janitor goroutine sweeping in the
+	// background.
+	if got := c.Len(); got != 0 {
+		t.Fatalf("got Len=%d, want the janitor to have swept the expired entry to 0", got)
+	}
+}
+
+func TestLRU_StopJanitorHaltsSweepingAndLeavesNoGoroutine(t *testing.T) {
+	c, err := NewLRUWithTTL(10, 5*time.Millisecond, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := runtime.NumGoroutine()
+
+	c.StartJanitor(2 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond) // let the janitor run at least once
+	c.StopJanitor()
+
+	if got := runtime.NumGoroutine(); got > before {
+		t.Fatalf("got %d goroutines after StopJanitor, want at most the pre-StartJanitor count of %d", got, before)
+	}
+
+	c.Add("a", 1, 1)
+	time.Sleep(30 * time.Millisecond) // well past the ttl, but the janitor is stopped
+	if got := c.Len(); got != 1 {
+		t.Fatalf("got Len=%d after StopJanitor, want the expired entry to still be there at 1", got)
+	}
+}
+
+func TestLRU_RemoveFiresInvalidationSink(t *testing.T) {
+	c, err := NewLRU(10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Name = "test-cache"
+	removed := make(chan InvalidationEvent, 1)
+	c.SetInvalidationSink(InvalidationSinkFunc{
+		Remove: func(name string, key interface{}) {
+			removed <- InvalidationEvent{CacheName: name, Key: key}
+		},
+	})
+
+	c.Add("a", 1, 1)
+	c.Remove("a")
+
+	select {
+	case ev := <-removed:
+		if ev.CacheName != "test-cache" || ev.Key != "a" {
+			t.Fatalf("got %+v, want CacheName=test-cache Key=a", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnRemove")
+	}
+}
@@ -0,0 +1,201 @@
+package simplelru
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"time"
+)
+
+// ShardedLRU stripes a cache across N independent, power-of-two shards,
+// each an LRU with its own mutex, so that Get/Add traffic against
+// different keys no longer serializes on a single lock. Each shard gets an
+// even fraction of the overall byte budget; Purge, Len, Size and Keys fan
+// out across all shards and merge the results.
+//
+// Keys returned by Keys, and the item picked by RemoveOldest/GetOldest, are
+// only ordered within a shard — ShardedLRU does not track a global
+// recency order across shards.
+//
+// onEvict is wired into every shard's own LRU, so unlike a plain LRU —
+// where a single mutex serializes every call into onEvict outside of
+// Purge — two shards
+// evicting at the same time can call onEvict concurrently from different
+// goroutines. A callback that isn't already safe to call from multiple
+// goroutines at once needs its own synchronization when used with
+// ShardedLRU.
+type ShardedLRU struct {
+	shards    []*LRU
+	shardMask uint64
+}
+
+var _ LRUCache = (*ShardedLRU)(nil)
+
+var shardHashSeed = maphash.MakeSeed()
+
+// NewShardedLRU constructs a sharded cache occupying approximately the
+// given size in memory, split evenly across shards. If shards <= 0, it
+// defaults to runtime.GOMAXPROCS(0) rounded up to the next power of two.
+func NewShardedLRU(sizeLimit, shards int, ttl time.Duration, onEvict EvictCallback) (*ShardedLRU, error) {
+	if sizeLimit <= 0 {
+		return nil, errors.New("Must provide a positive size limit")
+	}
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	shards = nextPowerOfTwo(shards)
+
+	perShard := sizeLimit / shards
+	if perShard < 1 {
+		perShard = 1
+	}
+
+	s := &ShardedLRU{
+		shards:    make([]*LRU, shards),
+		shardMask: uint64(shards - 1),
+	}
+	for i := range s.shards {
+		shard, err := NewLRUWithTTL(perShard, ttl, onEvict)
+		if err != nil {
+			return nil, err
+		}
+		s.shards[i] = shard
+	}
+	return s, nil
+}
+
+func nextPowerOfTwo(n int) int {
+	if n < 1 {
+		return 1
+	}
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// shardFor picks the shard a key belongs to. []byte and string take a
+// direct FNV-1a path; anything else falls back to hashing its %v
+// representation, since this package already treats keys as opaque
+// interface{} values with no required Hash method.
+func (s *ShardedLRU) shardFor(key interface{}) *LRU {
+	var h uint64
+	switch k := key.(type) {
+	case string:
+		h = fnv1a64String(k)
+	case []byte:
+		h = fnv1a64(k)
+	default:
+		h = maphash.String(shardHashSeed, fmt.Sprint(key))
+	}
+	return s.shards[h&s.shardMask]
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+func fnv1a64(data []byte) uint64 {
+	h := uint64(fnvOffset64)
+	for _, b := range data {
+		h ^= uint64(b)
+		h *= fnvPrime64
+	}
+	return h
+}
+
+func fnv1a64String(s string) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (s *ShardedLRU) Add(key, value interface{}, size int) bool {
+	return s.shardFor(key).Add(key, value, size)
+}
+
+// Get looks up a key's value from the cache.
+func (s *ShardedLRU) Get(key interface{}) (interface{}, bool) {
+	return s.shardFor(key).Get(key)
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (s *ShardedLRU) Contains(key interface{}) bool {
+	return s.shardFor(key).Contains(key)
+}
+
+// Peek returns the key value without updating the recent-ness of the key.
+func (s *ShardedLRU) Peek(key interface{}) (interface{}, bool) {
+	return s.shardFor(key).Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (s *ShardedLRU) Remove(key interface{}) bool {
+	return s.shardFor(key).Remove(key)
+}
+
+// RemoveOldest removes the oldest item from the first shard that has one.
+// "Oldest" is only meaningful within that shard; see the ShardedLRU doc
+// comment.
+func (s *ShardedLRU) RemoveOldest() (key, value interface{}, ok bool) {
+	for _, shard := range s.shards {
+		if key, value, ok = shard.RemoveOldest(); ok {
+			return key, value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// GetOldest returns the oldest entry from the first shard that has one. See
+// the ShardedLRU doc comment on ordering.
+func (s *ShardedLRU) GetOldest() (key, value interface{}, ok bool) {
+	for _, shard := range s.shards {
+		if key, value, ok = shard.GetOldest(); ok {
+			return key, value, true
+		}
+	}
+	return nil, nil, false
+}
+
+// Keys returns the keys of every shard concatenated together. Ordering is
+// preserved within each shard but not globally.
+func (s *ShardedLRU) Keys() []interface{} {
+	var keys []interface{}
+	for _, shard := range s.shards {
+		keys = append(keys, shard.Keys()...)
+	}
+	return keys
+}
+
+// Len returns the number of items across all shards.
+func (s *ShardedLRU) Len() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Len()
+	}
+	return n
+}
+
+// Size returns the current size across all shards.
+func (s *ShardedLRU) Size() int {
+	n := 0
+	for _, shard := range s.shards {
+		n += shard.Size()
+	}
+	return n
+}
+
+// Purge clears every shard.
+func (s *ShardedLRU) Purge() {
+	for _, shard := range s.shards {
+		shard.Purge()
+	}
+}
@@ -0,0 +1,49 @@
+package simplelru
+
+import (
+	"strconv"
+	"testing"
+)
+
+// BenchmarkLRU_Parallel measures a plain LRU under concurrent Add/Get
+// traffic, where every goroutine serializes on the same mutex.
+func BenchmarkLRU_Parallel(b *testing.B) {
+	c, err := NewLRU(1<<20, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkCacheParallel(b, c)
+}
+
+// BenchmarkShardedLRU_Parallel measures the same workload against a
+// ShardedLRU, which should scale with GOMAXPROCS instead of flattening out
+// once goroutines start contending for a single lock.
+func BenchmarkShardedLRU_Parallel(b *testing.B) {
+	c, err := NewShardedLRU(1<<20, 0, 0, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkCacheParallel(b, c)
+}
+
+func benchmarkCacheParallel(b *testing.B, c LRUCache) {
+	const keySpace = 1 << 12
+	keys := make([]string, keySpace)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%keySpace]
+			i++
+			if i%4 == 0 {
+				c.Add(key, i, 1)
+			} else {
+				c.Get(key)
+			}
+		}
+	})
+}
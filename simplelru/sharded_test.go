@@ -0,0 +1,50 @@
+package simplelru
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestShardedLRU_AddGetRoundTrip(t *testing.T) {
+	c, err := NewShardedLRU(100, 4, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 20; i++ {
+		c.Add(strconv.Itoa(i), i, 1)
+	}
+	for i := 0; i < 20; i++ {
+		v, ok := c.Get(strconv.Itoa(i))
+		if !ok || v != i {
+			t.Fatalf("got (%v, %v) for key %d, want (%d, true)", v, ok, i, i)
+		}
+	}
+	if c.Len() != 20 {
+		t.Fatalf("got Len=%d, want 20", c.Len())
+	}
+}
+
+func TestShardedLRU_ShardCountIsPowerOfTwo(t *testing.T) {
+	c, err := NewShardedLRU(100, 3, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(c.shards); got != 4 {
+		t.Fatalf("got %d shards for a requested count of 3, want 4", got)
+	}
+}
+
+func TestShardedLRU_PurgeClearsEveryShard(t *testing.T) {
+	c, err := NewShardedLRU(100, 4, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		c.Add(strconv.Itoa(i), i, 1)
+	}
+	c.Purge()
+	if c.Len() != 0 {
+		t.Fatalf("got Len=%d after Purge, want 0", c.Len())
+	}
+}
@@ -0,0 +1,302 @@
+package simplelru
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// default2QRecentRatio is the default fraction of the byte budget
+	// reserved for the recent (T1) sub-cache.
+	default2QRecentRatio = 0.25
+
+	// default2QGhostRatio is the default fraction of the byte budget used
+	// to size the recentEvict (ghost) sub-cache.
+	default2QGhostRatio = 0.50
+)
+
+// TwoQueueLRU implements a size-aware 2Q cache: entries are added to
+// recent, promoted to frequent on a second access, and a key evicted from
+// recent is remembered in a ghost cache (recentEvict) so that a subsequent
+// Add for that key is treated as a frequent entry right away instead of
+// restarting in recent. This protects frequently reused entries from being
+// evicted by a single scan through many one-hit keys.
+type TwoQueueLRU struct {
+	mu sync.Mutex
+
+	sizeLimit       int
+	recentSizeLimit int
+	recent          *LRU
+	frequent        *LRU
+	recentEvict     *LRU
+	onEvict         EvictCallback
+}
+
+var _ LRUCache = (*TwoQueueLRU)(nil)
+
+// NewTwoQueueLRU constructs a 2Q cache using the default recent/ghost
+// ratios.
+func NewTwoQueueLRU(sizeLimit int, ttl time.Duration, onEvict EvictCallback) (*TwoQueueLRU, error) {
+	return NewTwoQueueLRUParams(sizeLimit, default2QRecentRatio, default2QGhostRatio, ttl, onEvict)
+}
+
+// NewTwoQueueLRUParams constructs a 2Q cache with explicit recent/ghost
+// ratios, each expressed as a fraction of sizeLimit in (0, 1].
+func NewTwoQueueLRUParams(sizeLimit int, recentRatio, ghostRatio float64, ttl time.Duration, onEvict EvictCallback) (*TwoQueueLRU, error) {
+	if sizeLimit <= 0 {
+		return nil, errors.New("Must provide a positive size limit")
+	}
+	if recentRatio <= 0 || recentRatio > 1 {
+		return nil, errors.New("Recent ratio must be in (0, 1]")
+	}
+	if ghostRatio <= 0 || ghostRatio > 1 {
+		return nil, errors.New("Ghost ratio must be in (0, 1]")
+	}
+
+	recentSizeLimit := int(float64(sizeLimit) * recentRatio)
+	if recentSizeLimit < 1 {
+		recentSizeLimit = 1
+	}
+	ghostSizeLimit := int(float64(sizeLimit) * ghostRatio)
+	if ghostSizeLimit < 1 {
+		ghostSizeLimit = 1
+	}
+
+	c := &TwoQueueLRU{
+		sizeLimit:       sizeLimit,
+		recentSizeLimit: recentSizeLimit,
+		onEvict:         onEvict,
+	}
+
+	// recent and frequent are given no onEvict of their own: an internal
+	// Remove (used to promote a key from recent to frequent) must not look
+	// like the key left the cache. TwoQueueLRU decides for itself, in
+	// ensureSpace, when an entry has genuinely been evicted and needs to be
+	// ghosted and reported to the caller's callback.
+	recent, err := NewLRUWithTTL(sizeLimit, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	frequent, err := NewLRUWithTTL(sizeLimit, ttl, nil)
+	if err != nil {
+		return nil, err
+	}
+	recentEvict, err := NewLRU(ghostSizeLimit, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.recent = recent
+	c.frequent = frequent
+	c.recentEvict = recentEvict
+	return c, nil
+}
+
+// Add adds a value to the cache. Returns true if an eviction occurred.
+func (c *TwoQueueLRU) Add(key, value interface{}, size int) (evicted bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.frequent.Contains(key) {
+		evicted = c.ensureSpaceForUpdate(c.frequent, key, size)
+		c.frequent.Add(key, value, size)
+		return evicted
+	}
+
+	if c.recentEvict.Contains(key) {
+		// Ghost hit: this key was popular enough to be evicted from
+		// recent before being reused. Skip recent entirely.
+		c.recentEvict.Remove(key)
+		evicted = c.ensureSpace(size)
+		c.frequent.Add(key, value, size)
+		return evicted
+	}
+
+	if c.recent.Contains(key) {
+		evicted = c.ensureSpaceForUpdate(c.recent, key, size)
+		c.recent.Add(key, value, size)
+		return evicted
+	}
+
+	evicted = c.ensureSpace(size)
+	c.recent.Add(key, value, size)
+	if c.spillRecentToLimit() {
+		evicted = true
+	}
+	return evicted
+}
+
+// Get looks up a key's value from the cache, promoting it from recent to
+// frequent on a second access.
+func (c *TwoQueueLRU) Get(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, ok = c.frequent.Get(key); ok {
+		return value, true
+	}
+
+	if value, ok = c.recent.Peek(key); ok {
+		size, _ := c.recent.peekSize(key)
+		c.recent.Remove(key)
+		c.ensureSpace(size)
+		c.frequent.Add(key, value, size)
+		return value, true
+	}
+
+	return nil, false
+}
+
+// Contains checks if a key is in the cache, without updating the
+// recent-ness.
+func (c *TwoQueueLRU) Contains(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.frequent.Contains(key) || c.recent.Contains(key)
+}
+
+// Peek returns the key value without updating the recent-ness of the key.
+func (c *TwoQueueLRU) Peek(key interface{}) (value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if value, ok = c.frequent.Peek(key); ok {
+		return value, true
+	}
+	return c.recent.Peek(key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *TwoQueueLRU) Remove(key interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.frequent.Remove(key) {
+		return true
+	}
+	if c.recent.Remove(key) {
+		return true
+	}
+	return c.recentEvict.Remove(key)
+}
+
+// RemoveOldest removes the oldest item from the cache, preferring recent
+// over frequent as dictated by the 2Q policy.
+func (c *TwoQueueLRU) RemoveOldest() (key, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, value, ok = c.recent.RemoveOldest(); ok {
+		return key, value, true
+	}
+	return c.frequent.RemoveOldest()
+}
+
+// GetOldest returns the oldest entry, preferring recent over frequent.
+func (c *TwoQueueLRU) GetOldest() (key, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, value, ok = c.recent.GetOldest(); ok {
+		return key, value, true
+	}
+	return c.frequent.GetOldest()
+}
+
+// Keys returns a slice of the keys in the cache, from oldest to newest
+// within each sub-cache; recent keys are listed before frequent keys.
+func (c *TwoQueueLRU) Keys() []interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append(c.recent.Keys(), c.frequent.Keys()...)
+}
+
+// Len returns the number of items in the cache.
+func (c *TwoQueueLRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recent.Len() + c.frequent.Len()
+}
+
+// Size returns the current size of the cache, excluding the ghost cache.
+func (c *TwoQueueLRU) Size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recent.Size() + c.frequent.Size()
+}
+
+// Purge clears recent, frequent and the ghost cache.
+func (c *TwoQueueLRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recent.Purge()
+	c.frequent.Purge()
+	c.recentEvict.Purge()
+}
+
+// ensureSpaceForUpdate makes room for an in-place size change of a key
+// already resident in sub (recent or frequent), whose old size is still
+// counted in recent.Size()+frequent.Size(). Only the growth, not the full
+// new size, needs to be accounted for: shrinking or same-size updates need
+// no eviction at all. c.mu must be held.
+func (c *TwoQueueLRU) ensureSpaceForUpdate(sub *LRU, key interface{}, newSize int) (evicted bool) {
+	oldSize, _ := sub.peekSize(key)
+	if delta := newSize - oldSize; delta > 0 {
+		return c.ensureSpace(delta)
+	}
+	return false
+}
+
+// ensureSpace first caps recent to its ratio of the byte budget, then
+// evicts from recent, then frequent, until adding an entry of the given
+// size would not push the combined byte budget over sizeLimit. c.mu must
+// be held. recent and frequent are constructed with onEvict: nil (see
+// NewTwoQueueLRUParams), so this is the only place a key is ghosted and
+// reported to the caller's callback as a genuine, size-driven eviction.
+func (c *TwoQueueLRU) ensureSpace(size int) (evicted bool) {
+	if c.spillRecentToLimit() {
+		evicted = true
+	}
+	for c.recent.Size()+c.frequent.Size()+size > c.sizeLimit {
+		if c.recent.Size() > 0 {
+			if !c.ghostifyRecentOldest() {
+				break
+			}
+		} else {
+			key, value, fsize, ok := c.frequent.removeOldestWithSize()
+			if !ok {
+				break
+			}
+			if c.onEvict != nil {
+				c.onEvict(key, value, fsize, EvictReasonSize)
+			}
+		}
+		evicted = true
+	}
+	return evicted
+}
+
+// spillRecentToLimit evicts from recent, ghosting each evicted key, until
+// recent is back at or under its ratio of the byte budget. c.mu must be
+// held.
+func (c *TwoQueueLRU) spillRecentToLimit() (evicted bool) {
+	for c.recent.Size() > c.recentSizeLimit {
+		if !c.ghostifyRecentOldest() {
+			break
+		}
+		evicted = true
+	}
+	return evicted
+}
+
+// ghostifyRecentOldest evicts the oldest entry in recent, remembers it in
+// the ghost cache, and reports it to the caller's callback as a genuine
+// eviction. c.mu must be held.
+func (c *TwoQueueLRU) ghostifyRecentOldest() bool {
+	key, value, size, ok := c.recent.removeOldestWithSize()
+	if !ok {
+		return false
+	}
+	c.recentEvict.Add(key, nil, size)
+	if c.onEvict != nil {
+		c.onEvict(key, value, size, EvictReasonSize)
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package simplelru
+
+import "testing"
+
+// TestTwoQueueLRU_PromotionDoesNotEvict is a regression test: promoting a
+// key from recent to frequent on its second Get must not look like an
+// eviction to the caller's onEvict callback, since the key is still live.
+func TestTwoQueueLRU_PromotionDoesNotEvict(t *testing.T) {
+	var evicted []interface{}
+	c, err := NewTwoQueueLRU(10, 0, func(key, _ interface{}, _ int, _ EvictReason) {
+		evicted = append(evicted, key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be present")
+	}
+	if len(evicted) != 0 {
+		t.Fatalf("got evicted %v from a live promotion, want none", evicted)
+	}
+	if !c.Contains("a") {
+		t.Fatal("a should still be in the cache after promotion")
+	}
+}
+
+func TestTwoQueueLRU_GhostHitGoesStraightToFrequent(t *testing.T) {
+	c, err := NewTwoQueueLRUParams(4, 0.5, 1.0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	c.Add("c", 3, 1)
+	// recent's ratio limit is now exceeded, spilling a into the ghost cache.
+	c.Add("d", 4, 1)
+	if !c.recentEvict.Contains("a") {
+		t.Fatal("a should have been ghosted out of recent")
+	}
+
+	c.Add("a", 6, 1)
+	if _, ok := c.frequent.Peek("a"); !ok {
+		t.Fatal("a should be re-admitted straight into frequent on a ghost hit")
+	}
+}
+
+// TestTwoQueueLRU_UpdateInPlaceRespectsSizeLimit is a regression test:
+// growing the size of a key that is already resident in frequent must make
+// room the same way a brand new key does, instead of writing the new size
+// straight in and blowing past sizeLimit.
+func TestTwoQueueLRU_UpdateInPlaceRespectsSizeLimit(t *testing.T) {
+	c, err := NewTwoQueueLRU(10, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Get("a") // promote a into frequent
+	if _, ok := c.frequent.Peek("a"); !ok {
+		t.Fatal("a should have been promoted to frequent")
+	}
+	c.Add("b", 2, 1)
+	c.Add("c", 3, 1)
+	c.Add("d", 4, 1) // spills b into the ghost cache, leaving recent = {c, d}
+
+	c.Add("a", 5, 9) // growing a by 8 bytes no longer fits alongside both
+	if got := c.Size(); got > 10 {
+		t.Fatalf("got Size=%d after growing a resident key, want at most 10", got)
+	}
+	if c.Contains("c") {
+		t.Fatal("c should have been evicted to make room for a's growth")
+	}
+}
+
+func TestTwoQueueLRU_EvictsOverSizeLimit(t *testing.T) {
+	var evicted int
+	c, err := NewTwoQueueLRU(2, 0, func(interface{}, interface{}, int, EvictReason) {
+		evicted++
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add("a", 1, 1)
+	c.Add("b", 2, 1)
+	c.Add("c", 3, 1)
+
+	if c.Len() > 2 {
+		t.Fatalf("got Len=%d, want at most 2", c.Len())
+	}
+	if evicted == 0 {
+		t.Fatal("expected at least one onEvict call")
+	}
+}